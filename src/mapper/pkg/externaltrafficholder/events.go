@@ -0,0 +1,106 @@
+package externaltrafficholder
+
+import (
+	"sync"
+	"time"
+)
+
+// IntentEventType describes what happened to an entry in one of the holder's bounded stores.
+type IntentEventType string
+
+const (
+	IntentEventAdded   IntentEventType = "added"
+	IntentEventMerged  IntentEventType = "merged"
+	IntentEventEvicted IntentEventType = "evicted"
+	IntentEventDropped IntentEventType = "dropped"
+)
+
+// IntentEvent records a single mutation of a bounded store, including why an entry was evicted or
+// dropped, so subscribers can see the reason rather than inferring it from a gap in the data.
+type IntentEvent struct {
+	Type      IntentEventType
+	Key       ExternalTrafficKey
+	Store     string
+	Timestamp time.Time
+	Reason    string
+}
+
+type ExternalTrafficEventCallbackFunc func(IntentEvent)
+
+// eventPublishBufferSize bounds the hand-off between recordEvent (called under h.lock) and the
+// event fan-out goroutine, mirroring publishBufferSize in subscribe.go so a slow or blocking
+// RegisterNotifyEvents callback can't stall AddIntent/GetNewIntentsSinceLastGet.
+const eventPublishBufferSize = 1024
+
+// runEventFanOut is the holder's single event fan-out goroutine: it owns delivery to every
+// eventCallbacks entry so recordEvent only ever has to do a non-blocking send into h.eventPublish.
+func (h *ExternalTrafficIntentsHolder) runEventFanOut() {
+	for event := range h.eventPublish {
+		h.eventCallbacksMu.Lock()
+		callbacks := h.eventCallbacks
+		h.eventCallbacksMu.Unlock()
+
+		for _, callback := range callbacks {
+			callback(event)
+		}
+	}
+}
+
+// publishEvent hands event off to the event fan-out goroutine. Non-blocking: if the hand-off
+// buffer is full, the event is dropped from delivery (it remains available via RecentEvents,
+// which reads the ring buffer directly) rather than stalling the caller.
+func (h *ExternalTrafficIntentsHolder) publishEvent(event IntentEvent) {
+	h.eventFanOutOnce.Do(func() { go h.runEventFanOut() })
+
+	select {
+	case h.eventPublish <- event:
+	default:
+		eventPublishDroppedTotal.Inc()
+	}
+}
+
+// eventRing is a fixed-size ring buffer of the most recently seen intent events. Bounding it
+// separately from the intent stores means a burst of evictions can't grow memory on its own, and a
+// newly attached subscriber can still catch up on recent history via snapshot.
+type eventRing struct {
+	mu     sync.Mutex
+	events []IntentEvent
+	next   int
+	filled bool
+}
+
+func newEventRing(capacity int) *eventRing {
+	return &eventRing{events: make([]IntentEvent, capacity)}
+}
+
+func (r *eventRing) push(e IntentEvent) {
+	if len(r.events) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events[r.next] = e
+	r.next = (r.next + 1) % len(r.events)
+	if r.next == 0 {
+		r.filled = true
+	}
+}
+
+// snapshot returns the buffered events in chronological order.
+func (r *eventRing) snapshot() []IntentEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.filled {
+		out := make([]IntentEvent, r.next)
+		copy(out, r.events[:r.next])
+		return out
+	}
+
+	out := make([]IntentEvent, len(r.events))
+	n := copy(out, r.events[r.next:])
+	copy(out[n:], r.events[:r.next])
+	return out
+}
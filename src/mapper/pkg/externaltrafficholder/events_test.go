@@ -0,0 +1,109 @@
+package externaltrafficholder
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DrDroidLab/droid-network-mapper/src/mapper/pkg/graph/model"
+)
+
+// TestRecordEventDoesNotBlockOnSlowCallback ensures a RegisterNotifyEvents callback that blocks
+// forever can't stall AddIntent, which calls recordEvent while holding h.lock.
+func TestRecordEventDoesNotBlockOnSlowCallback(t *testing.T) {
+	h := NewExternalTrafficIntentsHolder(context.Background(), nil)
+
+	block := make(chan struct{})
+	h.RegisterNotifyEvents(func(IntentEvent) {
+		<-block
+	})
+	defer close(block)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.AddIntent(IPExternalTrafficIntent{
+			Client:   model.OtterizeServiceIdentity{Name: "client", Namespace: "default"},
+			LastSeen: time.Now(),
+			IP:       "1.1.1.1",
+		}, FlowKey{}, FlowStats{})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("AddIntent blocked on a slow event callback")
+	}
+}
+
+// TestRecordEventDeliversToAllCallbacks checks events still reach every registered callback once
+// they're no longer delivered synchronously under h.lock.
+func TestRecordEventDeliversToAllCallbacks(t *testing.T) {
+	h := NewExternalTrafficIntentsHolder(context.Background(), nil)
+
+	var mu sync.Mutex
+	var received []IntentEventType
+	var wg sync.WaitGroup
+	const callbackCount = 2
+	wg.Add(callbackCount)
+	for i := 0; i < callbackCount; i++ {
+		h.RegisterNotifyEvents(func(e IntentEvent) {
+			mu.Lock()
+			received = append(received, e.Type)
+			mu.Unlock()
+			wg.Done()
+		})
+	}
+
+	h.AddIntent(IPExternalTrafficIntent{
+		Client:   model.OtterizeServiceIdentity{Name: "client", Namespace: "default"},
+		LastSeen: time.Now(),
+		IP:       "1.1.1.1",
+	}, FlowKey{}, FlowStats{})
+
+	waitDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(waitDone)
+	}()
+
+	select {
+	case <-waitDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("event callback was never invoked")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != callbackCount || received[0] != IntentEventAdded || received[1] != IntentEventAdded {
+		t.Fatalf("expected one %q event per callback, got %v", IntentEventAdded, received)
+	}
+}
+
+// TestRegisterNotifyEventsRaceWithFanOut reproduces the race flagged in review: RegisterNotifyEvents
+// appends to eventCallbacks while runEventFanOut may already be ranging over it concurrently, once
+// traffic has started the fan-out goroutine. Run with -race.
+func TestRegisterNotifyEventsRaceWithFanOut(t *testing.T) {
+	h := NewExternalTrafficIntentsHolder(context.Background(), nil)
+	client := model.OtterizeServiceIdentity{Name: "client", Namespace: "default"}
+
+	h.RegisterNotifyEvents(func(IntentEvent) {})
+	h.AddIntent(IPExternalTrafficIntent{Client: client, LastSeen: time.Now(), IP: "1.1.1.1"}, FlowKey{}, FlowStats{})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			h.AddIntent(IPExternalTrafficIntent{Client: client, LastSeen: time.Now(), IP: "2.2.2.2"}, FlowKey{}, FlowStats{})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			h.RegisterNotifyEvents(func(IntentEvent) {})
+		}
+	}()
+	wg.Wait()
+}
@@ -8,10 +8,41 @@ import (
 	"github.com/DrDroidLab/droid-network-mapper/src/mapper/pkg/config"
 	"github.com/DrDroidLab/droid-network-mapper/src/mapper/pkg/graph/model"
 	"github.com/sirupsen/logrus"
+	"os"
+	"os/signal"
 	"sync"
+	"syscall"
 	"time"
 )
 
+// DefaultMaxAge is the longest an entry may live without being re-observed, regardless of its
+// DNS TTL. It also remains the purge window for IP-only intents, which carry no TTL of their own.
+const DefaultMaxAge = time.Hour
+
+// storeEstablished holds DNS-resolved intents and IP-only intents that have been otherwise
+// confirmed by reaching establishedPromotionThreshold observed connections; storeAttempted holds
+// IP-only endpoints that haven't yet been resolved or confirmed. Each is bounded separately so a
+// scan/amplification burst of unresolved IPs can't starve capacity from known-good entries.
+const (
+	storeEstablished = "established"
+	storeAttempted   = "attempted"
+)
+
+// establishedPromotionThreshold is the number of observed connections an IP-only intent needs
+// before it is promoted out of the small storeAttempted LRU into storeEstablished, so a
+// frequently-hit destination that never resolves via DNS doesn't stay capped behind (and get
+// evicted ahead of) newer, less-significant IP-only traffic. Operator-configurable via
+// config.ExternalTrafficEstablishedPromotionThreshold.
+func establishedPromotionThreshold() int {
+	return config.ExternalTrafficEstablishedPromotionThreshold()
+}
+
+// defaultFlowCap bounds the fine-grained flow store. The established/attempted intent store caps
+// and the event buffer cap are operator-configurable (config.ExternalTrafficEstablishedCap/
+// AttemptedCap/EventBufferCap) since a cluster that talks to many egress IPs (scrapers, DNS
+// amplification, scanning) would otherwise grow these maps without limit between purge cycles.
+const defaultFlowCap = 20000
+
 type ExternalTrafficIntent interface {
 	GetClient() model.OtterizeServiceIdentity
 	GetKey() ExternalTrafficKey
@@ -20,14 +51,35 @@ type ExternalTrafficIntent interface {
 
 type IP string
 
+// dnsIPRecord tracks a single IP resolved for a DNS name, with its own expiry so that one
+// short-lived IP in a recordset doesn't keep the whole DNS intent alive, and so the IP can
+// individually be dropped once its TTL lapses.
+type dnsIPRecord struct {
+	LastSeen  time.Time
+	ExpiresAt time.Time
+}
+
 type DNSExternalTrafficIntent struct {
 	Client   model.OtterizeServiceIdentity `json:"client"`
 	LastSeen time.Time
 	DNSName  string
-	IPs      map[IP]struct{}
+	IPs      map[IP]dnsIPRecord
 	TTL      time.Time
 }
 
+// cloneForEmit returns a copy of i with its own independent copy of IPs. GetNewIntentsSinceLastGet
+// hands its return value to callbacks outside h.lock while the original IPs map is retained (and
+// later mutated in place by AddIntent/mergeIPIntoDNS) in the store's copy, so without this the two
+// would alias the same map and race. Mirrors the copy-on-emit treatment FlowStatsReset gives Flow.
+func (i DNSExternalTrafficIntent) cloneForEmit() DNSExternalTrafficIntent {
+	ips := make(map[IP]dnsIPRecord, len(i.IPs))
+	for ip, record := range i.IPs {
+		ips[ip] = record
+	}
+	i.IPs = ips
+	return i
+}
+
 type IPExternalTrafficIntent struct {
 	Client   model.OtterizeServiceIdentity `json:"client"`
 	LastSeen time.Time
@@ -54,6 +106,27 @@ type TimestampedExternalTrafficIntent struct {
 	Timestamp        time.Time
 	Intent           ExternalTrafficIntent
 	ConnectionsCount *cloudclient.ConnectionsCount
+	// Flow accumulates per-(client,dest) flow-level stats across every AddIntent call that
+	// collapses into this entry's ExternalTrafficKey. It is reset to FlowStatsReset() each time
+	// the entry is emitted from GetNewIntentsSinceLastGet, so callbacks see per-interval deltas.
+	Flow FlowStats
+	// ObservedCount counts the AddIntent calls that have created or merged into an IP-only entry;
+	// DNS entries leave it unchanged. An IP-only intent is promoted from storeAttempted into storeEstablished once it reaches
+	// establishedPromotionThreshold, even though it never resolved via DNS.
+	ObservedCount int
+}
+
+// cloneForEmit returns a copy of t with independent copies of its mutable map fields (a DNS
+// intent's IPs, and Flow.DestPorts). Any copy that escapes outside h.lock to run unsynchronized -
+// to the Subscribe fan-out via publishUpsert, or to a Snapshotter.Save during checkpoint - must go
+// through this first, or it races with a later holder call still mutating the maps retained in
+// the store.
+func (t TimestampedExternalTrafficIntent) cloneForEmit() TimestampedExternalTrafficIntent {
+	if dnsIntent, ok := t.Intent.(DNSExternalTrafficIntent); ok {
+		t.Intent = dnsIntent.cloneForEmit()
+	}
+	t.Flow = t.Flow.cloneForEmit()
+	return t
 }
 
 func (i DNSExternalTrafficIntent) GetClient() model.OtterizeServiceIdentity {
@@ -83,18 +156,134 @@ type ExternalTrafficKey struct {
 type IntentsConnectionCounter map[ExternalTrafficKey]*concurrentconnectioncounter.ConnectionCounter[*concurrentconnectioncounter.CountableIntentExternalTrafficIntent]
 
 type ExternalTrafficIntentsHolder struct {
-	intentsNoDelay   map[ExternalTrafficKey]TimestampedExternalTrafficIntent
-	delayedIPIntents map[ExternalTrafficKey]TimestampedExternalTrafficIntent
+	// intentsNoDelay holds established intents (DNS-resolved or otherwise confirmed) ready to be
+	// reported on the next upload. delayedIPIntents holds attempted/unresolved IP-only intents
+	// held back one iteration, per the DNS-before-IP ordering invariant documented on AddIntent.
+	intentsNoDelay   *boundedIntentStore
+	delayedIPIntents *boundedIntentStore
 	lock             sync.Mutex
 	callbacks        []ExternalTrafficCallbackFunc
+	events           *eventRing
+
+	// Event fan-out plumbing: recordEvent (called while holding h.lock) hands events off to
+	// eventPublish non-blockingly, and the single runEventFanOut goroutine delivers them to
+	// eventCallbacks, so a slow or blocking RegisterNotifyEvents callback can't stall AddIntent.
+	// eventCallbacksMu guards eventCallbacks itself, since runEventFanOut now reads it from a
+	// goroutine that runs independently of h.lock.
+	eventCallbacksMu sync.Mutex
+	eventCallbacks   []ExternalTrafficEventCallbackFunc
+	eventPublish     chan IntentEvent
+	eventFanOutOnce  sync.Once
+
+	// Subscribe plumbing: AddIntent hands post-merge state to publish non-blockingly, and the
+	// single runFanOut goroutine delivers it to subscribers.
+	subsLock    sync.Mutex
+	subscribers map[uint64]*subscriber
+	nextSubID   uint64
+	publish     chan TimestampedExternalTrafficIntent
+	fanOutOnce  sync.Once
+
+	snapshotter Snapshotter
+
+	// flows is the fine-grained 5-tuple dedup layer: it accumulates per-flow stats before they
+	// collapse into the coarser per-ExternalTrafficKey Flow total, so repeated short-lived
+	// connections to the same endpoint increment counters instead of overwriting each other.
+	flows *boundedFlowStore
 }
 
 type ExternalTrafficCallbackFunc func(context.Context, []TimestampedExternalTrafficIntent)
 
-func NewExternalTrafficIntentsHolder() *ExternalTrafficIntentsHolder {
-	return &ExternalTrafficIntentsHolder{
-		intentsNoDelay:   make(map[ExternalTrafficKey]TimestampedExternalTrafficIntent),
-		delayedIPIntents: make(map[ExternalTrafficKey]TimestampedExternalTrafficIntent),
+// NewExternalTrafficIntentsHolder constructs an empty holder. If snapshotter is non-nil, it is used
+// to restore pending intents left over from a previous run before the holder is returned, and later
+// to checkpoint state from PeriodicIntentsUpload. Pass nil to run without persistence.
+func NewExternalTrafficIntentsHolder(ctx context.Context, snapshotter Snapshotter) *ExternalTrafficIntentsHolder {
+	h := &ExternalTrafficIntentsHolder{
+		intentsNoDelay:   newBoundedIntentStore(config.ExternalTrafficEstablishedCap()),
+		delayedIPIntents: newBoundedIntentStore(config.ExternalTrafficAttemptedCap()),
+		events:           newEventRing(config.ExternalTrafficEventBufferCap()),
+		eventPublish:     make(chan IntentEvent, eventPublishBufferSize),
+		subscribers:      make(map[uint64]*subscriber),
+		publish:          make(chan TimestampedExternalTrafficIntent, publishBufferSize),
+		snapshotter:      snapshotter,
+		flows:            newBoundedFlowStore(defaultFlowCap),
+	}
+
+	h.loadSnapshot(ctx)
+
+	return h
+}
+
+// loadSnapshot restores intents saved by a previous run, discarding anything already past
+// DefaultMaxAge and routing each intent back into the store implied by its type so the
+// DNS-before-IP ordering invariant documented on AddIntent still holds after a restart.
+func (h *ExternalTrafficIntentsHolder) loadSnapshot(ctx context.Context) {
+	if h.snapshotter == nil {
+		return
+	}
+
+	intents, err := h.snapshotter.Load(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load external traffic intents snapshot, starting empty")
+		return
+	}
+
+	now := time.Now()
+	restored := 0
+	for _, intent := range intents {
+		if now.Sub(intent.Timestamp) >= DefaultMaxAge {
+			continue
+		}
+
+		switch intent.Intent.(type) {
+		case DNSExternalTrafficIntent:
+			h.intentsNoDelay.Set(intent.Intent.GetKey(), intent)
+			restored++
+		case IPExternalTrafficIntent:
+			// An IP intent that had already crossed establishedPromotionThreshold before the
+			// restart belongs back in storeEstablished, not storeAttempted, or it would lose its
+			// promoted status every time the mapper restarts.
+			if intent.ObservedCount >= establishedPromotionThreshold() {
+				h.intentsNoDelay.Set(intent.Intent.GetKey(), intent)
+			} else {
+				h.delayedIPIntents.Set(intent.Intent.GetKey(), intent)
+			}
+			restored++
+		}
+	}
+
+	logrus.Infof("Restored %d external traffic intents from snapshot", restored)
+}
+
+// allIntentsLocked returns every intent currently held, across both stores, for checkpointing.
+// Each intent is cloned since checkpoint calls Snapshotter.Save on the result after releasing
+// h.lock, and a held intent's map fields (a DNS intent's IPs, Flow.DestPorts) would otherwise
+// still be shared with - and race against - a concurrent AddIntent mutating the stored copy.
+// Callers must hold h.lock.
+func (h *ExternalTrafficIntentsHolder) allIntentsLocked() []TimestampedExternalTrafficIntent {
+	all := make([]TimestampedExternalTrafficIntent, 0, h.intentsNoDelay.Len()+h.delayedIPIntents.Len())
+	h.intentsNoDelay.Range(func(_ ExternalTrafficKey, intent TimestampedExternalTrafficIntent) bool {
+		all = append(all, intent.cloneForEmit())
+		return true
+	})
+	h.delayedIPIntents.Range(func(_ ExternalTrafficKey, intent TimestampedExternalTrafficIntent) bool {
+		all = append(all, intent.cloneForEmit())
+		return true
+	})
+	return all
+}
+
+// checkpoint saves the current state via the configured Snapshotter, if any.
+func (h *ExternalTrafficIntentsHolder) checkpoint(ctx context.Context) {
+	if h.snapshotter == nil {
+		return
+	}
+
+	h.lock.Lock()
+	snapshot := h.allIntentsLocked()
+	h.lock.Unlock()
+
+	if err := h.snapshotter.Save(ctx, snapshot); err != nil {
+		logrus.WithError(err).Error("Failed to save external traffic intents snapshot")
 	}
 }
 
@@ -102,9 +291,62 @@ func (h *ExternalTrafficIntentsHolder) RegisterNotifyIntents(callback ExternalTr
 	h.callbacks = append(h.callbacks, callback)
 }
 
+// RegisterNotifyEvents subscribes callback to added/merged/evicted/dropped events on the bounded
+// intent stores, so consumers can observe eviction reasons instead of inferring them from a gap in
+// the reported intents.
+func (h *ExternalTrafficIntentsHolder) RegisterNotifyEvents(callback ExternalTrafficEventCallbackFunc) {
+	h.eventCallbacksMu.Lock()
+	defer h.eventCallbacksMu.Unlock()
+	h.eventCallbacks = append(h.eventCallbacks, callback)
+}
+
+// RecentEvents returns a snapshot of the most recently recorded store events, oldest first.
+func (h *ExternalTrafficIntentsHolder) RecentEvents() []IntentEvent {
+	return h.events.snapshot()
+}
+
+// FlowStatsForKey returns the cumulative fine-grained flow stats tracked for a single 5-tuple,
+// independent of the coarser per-interval FlowStats reported on TimestampedExternalTrafficIntent.
+func (h *ExternalTrafficIntentsHolder) FlowStatsForKey(key FlowKey) (FlowStats, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.flows.Get(key)
+}
+
+// recordEvent buffers an event and hands it off to publishEvent for delivery to eventCallbacks.
+// Must be called while holding h.lock so event ordering matches store mutation ordering.
+func (h *ExternalTrafficIntentsHolder) recordEvent(eventType IntentEventType, store string, key ExternalTrafficKey, now time.Time, reason string) {
+	event := IntentEvent{
+		Type:      eventType,
+		Key:       key,
+		Store:     store,
+		Timestamp: now,
+		Reason:    reason,
+	}
+	h.events.push(event)
+
+	switch eventType {
+	case IntentEventAdded:
+		intentsAddedTotal.WithLabelValues(store).Inc()
+	case IntentEventMerged:
+		intentsMergedTotal.WithLabelValues(store).Inc()
+	case IntentEventEvicted:
+		intentsEvictedTotal.WithLabelValues(store).Inc()
+	case IntentEventDropped:
+		intentsDroppedTotal.WithLabelValues(reason).Inc()
+	}
+
+	h.publishEvent(event)
+}
+
 func (h *ExternalTrafficIntentsHolder) PeriodicIntentsUpload(ctx context.Context, interval time.Duration) {
 	logrus.Info("Starting periodic external traffic intents upload")
 
+	sigterm := make(chan os.Signal, 1)
+	signal.Notify(sigterm, syscall.SIGTERM)
+	defer signal.Stop(sigterm)
+
 	for {
 		select {
 		case <-time.After(interval):
@@ -119,13 +361,109 @@ func (h *ExternalTrafficIntentsHolder) PeriodicIntentsUpload(ctx context.Context
 			for _, callback := range h.callbacks {
 				callback(ctx, intents)
 			}
+			h.checkpoint(ctx)
+
+		case <-sigterm:
+			logrus.Info("Received SIGTERM, checkpointing external traffic intents")
+			h.checkpoint(ctx)
 
 		case <-ctx.Done():
+			h.checkpoint(ctx)
 			return
 		}
 	}
 }
 
+// clampDNSIPExpiry computes the expiry instant for a DNS-resolved IP: the DNS-reported TTL,
+// bounded to [minTTL, maxTTL], and never further out than DefaultMaxAge from lastSeen. minTTL and
+// maxTTL are the operator-configured floor/ceiling (config.ExternalTrafficMinDNSIntentTTL/
+// MaxDNSIntentTTL), so a pathological record (TTL=0, or a multi-day TTL) can't thrash the holder
+// or pin an IP alive indefinitely.
+func clampDNSIPExpiry(lastSeen time.Time, ttl time.Time, minTTL, maxTTL time.Duration) time.Time {
+	d := ttl.Sub(lastSeen)
+	if d < minTTL {
+		d = minTTL
+	} else if d > maxTTL {
+		d = maxTTL
+	}
+
+	expiresAt := lastSeen.Add(d)
+	if maxExpiry := lastSeen.Add(DefaultMaxAge); expiresAt.After(maxExpiry) {
+		return maxExpiry
+	}
+	return expiresAt
+}
+
+// findLiveDNSRecordForIP looks for a DNS intent belonging to client that still has a non-expired
+// sub-record for ip, so a sniffed IP-only packet captured after resolution can still be attributed
+// to the DNS name that produced it.
+func (h *ExternalTrafficIntentsHolder) findLiveDNSRecordForIP(client model.OtterizeServiceIdentity, ip IP, now time.Time) (ExternalTrafficKey, bool) {
+	key, _, found := h.intentsNoDelay.Find(func(key ExternalTrafficKey, timestampedIntent TimestampedExternalTrafficIntent) bool {
+		if key.ClientName != client.Name || key.ClientNamespace != client.Namespace {
+			return false
+		}
+
+		dnsIntent, ok := timestampedIntent.Intent.(DNSExternalTrafficIntent)
+		if !ok {
+			return false
+		}
+
+		record, ok := dnsIntent.IPs[ip]
+		return ok && now.Before(record.ExpiresAt)
+	})
+	return key, found
+}
+
+// mergeIPObservation folds a newly observed lastSeen/flow into a copy of existing, bumping its
+// Timestamp and ObservedCount. Shared by both IP-only merge paths in AddIntent so the established
+// and attempted tiers can't drift apart on how an observation updates an entry.
+func mergeIPObservation(existing TimestampedExternalTrafficIntent, lastSeen time.Time, flow FlowStats) TimestampedExternalTrafficIntent {
+	merged := existing
+	if lastSeen.After(merged.Timestamp) {
+		merged.Timestamp = lastSeen
+	}
+	merged.Flow = merged.Flow.merge(flow)
+	merged.ObservedCount++
+	return merged
+}
+
+// mergeIPIntoDNS folds a delayed IP intent into the DNS intent that resolved it, refreshing the
+// client's LastSeen on that IP without extending its TTL-derived expiry, and carrying over any
+// flow stats the IP intent had accumulated rather than discarding them.
+func (h *ExternalTrafficIntentsHolder) mergeIPIntoDNS(dnsKey ExternalTrafficKey, delayedIntent TimestampedExternalTrafficIntent, now time.Time) {
+	ipIntent := delayedIntent.Intent.(IPExternalTrafficIntent)
+
+	mergedIntent, _ := h.intentsNoDelay.Get(dnsKey)
+	dnsIntent := mergedIntent.Intent.(DNSExternalTrafficIntent)
+
+	if record, ok := dnsIntent.IPs[ipIntent.IP]; ok {
+		record.LastSeen = now
+		dnsIntent.IPs[ipIntent.IP] = record
+	}
+
+	if now.After(mergedIntent.Timestamp) {
+		mergedIntent.Timestamp = now
+	}
+	mergedIntent.Intent = dnsIntent
+	mergedIntent.Flow = mergedIntent.Flow.merge(delayedIntent.Flow)
+	h.intentsNoDelay.Set(dnsKey, mergedIntent)
+	h.recordEvent(IntentEventMerged, storeEstablished, dnsKey, now, "ip-promoted-to-dns")
+	h.publishUpsert(mergedIntent)
+}
+
+// promoteToEstablished moves an IP-only intent that has reached establishedPromotionThreshold
+// observed connections out of the small storeAttempted LRU and into storeEstablished, so it stops
+// competing for capacity with - and getting evicted ahead of - newer, less-significant IP-only
+// traffic.
+func (h *ExternalTrafficIntentsHolder) promoteToEstablished(key ExternalTrafficKey, intent TimestampedExternalTrafficIntent, now time.Time) {
+	h.delayedIPIntents.Delete(key)
+	if evicted := h.intentsNoDelay.Set(key, intent); evicted != nil {
+		h.recordEvent(IntentEventEvicted, storeEstablished, evicted.key, now, "capacity")
+	}
+	h.recordEvent(IntentEventMerged, storeEstablished, key, now, "observation-threshold")
+	h.publishUpsert(intent)
+}
+
 // GetNewIntentsSinceLastGet returns the intents that were added since the last call to this function. It also rotates the intentsNoDelay, so that the next call will return the intentsNoDelay that were added in the next iteration.
 func (h *ExternalTrafficIntentsHolder) GetNewIntentsSinceLastGet() []TimestampedExternalTrafficIntent {
 	h.lock.Lock()
@@ -133,41 +471,78 @@ func (h *ExternalTrafficIntentsHolder) GetNewIntentsSinceLastGet() []Timestamped
 
 	now := time.Now()
 
-	intents := make([]TimestampedExternalTrafficIntent, 0, len(h.intentsNoDelay))
+	intents := make([]TimestampedExternalTrafficIntent, 0, h.intentsNoDelay.Len())
+
+	// Collect intents still live; for DNS intents, expire individual IPs against their own TTL
+	// rather than dropping the whole entry, and only purge it once every IP has expired.
+	h.intentsNoDelay.Range(func(key ExternalTrafficKey, intent TimestampedExternalTrafficIntent) bool {
+		dnsIntent, isDNS := intent.Intent.(DNSExternalTrafficIntent)
+		if !isDNS {
+			if now.Sub(intent.Timestamp) < DefaultMaxAge {
+				intents = append(intents, intent)
+				intent.Flow = intent.Flow.FlowStatsReset()
+				h.intentsNoDelay.Set(key, intent)
+				return true
+			}
+			return false
+		}
 
-	// Collect only intents within the last hour; purge older
-	for key, intent := range h.intentsNoDelay {
-		if now.Sub(intent.Timestamp) < time.Hour {
-			intents = append(intents, intent)
-		} else {
-			delete(h.intentsNoDelay, key)
+		for ip, record := range dnsIntent.IPs {
+			if now.After(record.ExpiresAt) {
+				delete(dnsIntent.IPs, ip)
+			}
 		}
-	}
 
-	// Purge expired entries from delayed intents as well
-	for key, intent := range h.delayedIPIntents {
-		if now.Sub(intent.Timestamp) >= time.Hour {
-			delete(h.delayedIPIntents, key)
+		if len(dnsIntent.IPs) == 0 {
+			return false
 		}
-	}
 
-	// Rotate delayedIPIntents into intentsNoDelay by merging non-expired entries
-	for key, intent := range h.delayedIPIntents {
-		if now.Sub(intent.Timestamp) < time.Hour {
-			existing, ok := h.intentsNoDelay[key]
-			if !ok || intent.Timestamp.After(existing.Timestamp) {
-				h.intentsNoDelay[key] = intent
+		emitted := intent
+		emitted.Intent = dnsIntent.cloneForEmit()
+		intents = append(intents, emitted)
+
+		intent.Intent = dnsIntent
+		intent.Flow = intent.Flow.FlowStatsReset()
+		h.intentsNoDelay.Set(key, intent)
+		return true
+	})
+
+	// Purge expired entries, then report the survivors directly from storeAttempted. An IP intent
+	// that matches a still-live DNS sub-record for the same client is folded into that DNS intent
+	// instead, so downstream callbacks see DNS attribution even when the packet was sniffed after
+	// resolution. Anything else stays in storeAttempted: AddIntent is what promotes an entry into
+	// storeEstablished once it crosses establishedPromotionThreshold, so a burst of unconfirmed
+	// scan/amplification traffic can't ride this rotation straight into the established tier and
+	// starve its capacity.
+	h.delayedIPIntents.Range(func(key ExternalTrafficKey, intent TimestampedExternalTrafficIntent) bool {
+		if now.Sub(intent.Timestamp) >= DefaultMaxAge {
+			return false
+		}
+
+		if ipIntent, ok := intent.Intent.(IPExternalTrafficIntent); ok {
+			if dnsKey, found := h.findLiveDNSRecordForIP(ipIntent.Client, ipIntent.IP, now); found {
+				h.mergeIPIntoDNS(dnsKey, intent, now)
+				return false
 			}
 		}
-	}
-	h.delayedIPIntents = make(map[ExternalTrafficKey]TimestampedExternalTrafficIntent)
+
+		intents = append(intents, intent)
+		intent.Flow = intent.Flow.FlowStatsReset()
+		h.delayedIPIntents.Set(key, intent)
+		return true
+	})
 
 	return intents
 }
 
 // AddIntent adds a new external traffic intent to the holder. DNS intentsNoDelay are added to the current iteration, while IP intentsNoDelay are added to the next iteration. This is so that DNS traffic is reported first,
 // to allow Otterize Cloud to cache the DNS name and IPs before the IP intent is sent.
-func (h *ExternalTrafficIntentsHolder) AddIntent(intent ExternalTrafficIntent) {
+//
+// flowKey and flow describe the single connection that produced this intent. flowKey is first
+// merged into the fine-grained per-5-tuple flow store (so repeated short-lived connections to the
+// same endpoint accumulate instead of overwriting each other), then flow itself is folded into the
+// coarser per-ExternalTrafficKey running total.
+func (h *ExternalTrafficIntentsHolder) AddIntent(intent ExternalTrafficIntent, flowKey FlowKey, flow FlowStats) {
 	if config.ExcludedNamespaces().Contains(intent.GetClient().Namespace) {
 		return
 	}
@@ -177,53 +552,107 @@ func (h *ExternalTrafficIntentsHolder) AddIntent(intent ExternalTrafficIntent) {
 
 	// Proactively drop intents older than 1 hour to prevent growth
 	now := time.Now()
-	if now.Sub(intent.GetLastSeen()) >= time.Hour {
+	if now.Sub(intent.GetLastSeen()) >= DefaultMaxAge {
+		h.recordEvent(IntentEventDropped, "", intent.GetKey(), now, "stale")
 		return
 	}
 
+	h.flows.Merge(flowKey, flow)
+
 	key := intent.GetKey()
 
 	switch typedIntent := intent.(type) {
 	case DNSExternalTrafficIntent:
-		_, ok := h.intentsNoDelay[key]
+		lastSeen := intent.GetLastSeen()
+		expiresAt := clampDNSIPExpiry(lastSeen, typedIntent.TTL, config.ExternalTrafficMinDNSIntentTTL(), config.ExternalTrafficMaxDNSIntentTTL())
+
+		existing, ok := h.intentsNoDelay.Get(key)
 		if !ok {
-			h.intentsNoDelay[key] = TimestampedExternalTrafficIntent{
-				Timestamp: intent.GetLastSeen(),
-				Intent:    intent,
+			ips := make(map[IP]dnsIPRecord, len(typedIntent.IPs))
+			for ip := range typedIntent.IPs {
+				ips[ip] = dnsIPRecord{LastSeen: lastSeen, ExpiresAt: expiresAt}
 			}
+			typedIntent.IPs = ips
+			newIntent := TimestampedExternalTrafficIntent{
+				Timestamp: lastSeen,
+				Intent:    typedIntent,
+				Flow:      flow,
+			}
+			if evicted := h.intentsNoDelay.Set(key, newIntent); evicted != nil {
+				h.recordEvent(IntentEventEvicted, storeEstablished, evicted.key, now, "capacity")
+			}
+			h.recordEvent(IntentEventAdded, storeEstablished, key, now, "")
+			h.publishUpsert(newIntent)
 			return
 		}
 
-		mergedIntent := h.intentsNoDelay[key]
-		if intent.GetLastSeen().After(mergedIntent.Timestamp) {
-			mergedIntent.Timestamp = intent.GetLastSeen()
+		mergedIntent := existing
+		if lastSeen.After(mergedIntent.Timestamp) {
+			mergedIntent.Timestamp = lastSeen
 		}
 
+		merged := mergedIntent.Intent.(DNSExternalTrafficIntent)
+		if merged.IPs == nil {
+			merged.IPs = make(map[IP]dnsIPRecord)
+		}
 		for ip := range typedIntent.IPs {
-			merged := mergedIntent.Intent.(DNSExternalTrafficIntent)
-			if merged.IPs == nil {
-				merged.IPs = make(map[IP]struct{})
-			}
-			merged.IPs[ip] = struct{}{}
-			mergedIntent.Intent = merged
+			merged.IPs[ip] = dnsIPRecord{LastSeen: lastSeen, ExpiresAt: expiresAt}
 		}
-		h.intentsNoDelay[key] = mergedIntent
+		mergedIntent.Intent = merged
+		mergedIntent.Flow = mergedIntent.Flow.merge(flow)
+		h.intentsNoDelay.Set(key, mergedIntent)
+		h.recordEvent(IntentEventMerged, storeEstablished, key, now, "")
+		h.publishUpsert(mergedIntent)
 
 	case IPExternalTrafficIntent:
-		_, ok := h.delayedIPIntents[key]
+		// An IP-only intent that already graduated to storeEstablished must keep accumulating on
+		// its established entry - routing it back through storeAttempted here would reset
+		// ObservedCount/Flow to a fresh attempted entry and let the rotation below clobber the
+		// established one on its next pass.
+		if establishedIntent, ok := h.intentsNoDelay.Get(key); ok {
+			mergedIntent := mergeIPObservation(establishedIntent, intent.GetLastSeen(), flow)
+
+			// A DNS lookup resolving to this IP after it was already promoted shouldn't leave it
+			// stuck as a bare-IP established entry forever: fold it into the live DNS intent
+			// exactly as the rotation below does for storeAttempted entries.
+			if dnsKey, found := h.findLiveDNSRecordForIP(typedIntent.Client, typedIntent.IP, now); found {
+				h.intentsNoDelay.Delete(key)
+				h.mergeIPIntoDNS(dnsKey, mergedIntent, now)
+				return
+			}
+
+			h.intentsNoDelay.Set(key, mergedIntent)
+			h.recordEvent(IntentEventMerged, storeEstablished, key, now, "")
+			h.publishUpsert(mergedIntent)
+			return
+		}
+
+		existing, ok := h.delayedIPIntents.Get(key)
 		if !ok {
-			h.delayedIPIntents[key] = TimestampedExternalTrafficIntent{
-				Timestamp: intent.GetLastSeen(),
-				Intent:    intent,
+			newIntent := TimestampedExternalTrafficIntent{
+				Timestamp:     intent.GetLastSeen(),
+				Intent:        intent,
+				Flow:          flow,
+				ObservedCount: 1,
+			}
+			if evicted := h.delayedIPIntents.Set(key, newIntent); evicted != nil {
+				h.recordEvent(IntentEventEvicted, storeAttempted, evicted.key, now, "capacity")
 			}
+			h.recordEvent(IntentEventAdded, storeAttempted, key, now, "")
+			h.publishUpsert(newIntent)
 			return
 		}
 
-		mergedIntent := h.delayedIPIntents[key]
-		if intent.GetLastSeen().After(mergedIntent.Timestamp) {
-			mergedIntent.Timestamp = intent.GetLastSeen()
+		mergedIntent := mergeIPObservation(existing, intent.GetLastSeen(), flow)
+
+		if mergedIntent.ObservedCount >= establishedPromotionThreshold() {
+			h.promoteToEstablished(key, mergedIntent, now)
+			return
 		}
-		h.delayedIPIntents[key] = mergedIntent
+
+		h.delayedIPIntents.Set(key, mergedIntent)
+		h.recordEvent(IntentEventMerged, storeAttempted, key, now, "")
+		h.publishUpsert(mergedIntent)
 
 	default:
 		panic(fmt.Sprintf("Unexpected external traffic intent type: %T", intent))
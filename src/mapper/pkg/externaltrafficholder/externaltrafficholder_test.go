@@ -0,0 +1,82 @@
+package externaltrafficholder
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DrDroidLab/droid-network-mapper/src/mapper/pkg/graph/model"
+)
+
+// TestDNSIntentIPsNotSharedWithEmittedSnapshot reproduces the race flagged in review: a snapshot
+// returned from GetNewIntentsSinceLastGet must not share its DNSExternalTrafficIntent.IPs map with
+// the copy retained in the store, or a concurrent AddIntent mutating the live map races with a
+// goroutine still reading the previously emitted snapshot. Run with -race.
+func TestDNSIntentIPsNotSharedWithEmittedSnapshot(t *testing.T) {
+	h := NewExternalTrafficIntentsHolder(context.Background(), nil)
+	client := model.OtterizeServiceIdentity{Name: "client", Namespace: "default"}
+	now := time.Now()
+
+	h.AddIntent(DNSExternalTrafficIntent{
+		Client:   client,
+		LastSeen: now,
+		DNSName:  "example.com",
+		IPs:      map[IP]dnsIPRecord{"1.1.1.1": {}},
+		TTL:      now.Add(time.Minute),
+	}, FlowKey{}, FlowStats{})
+
+	snapshot := h.GetNewIntentsSinceLastGet()
+	if len(snapshot) != 1 {
+		t.Fatalf("expected 1 intent, got %d", len(snapshot))
+	}
+	emitted := snapshot[0].Intent.(DNSExternalTrafficIntent)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			h.AddIntent(DNSExternalTrafficIntent{
+				Client:   client,
+				LastSeen: time.Now(),
+				DNSName:  "example.com",
+				IPs:      map[IP]dnsIPRecord{"2.2.2.2": {}},
+				TTL:      time.Now().Add(time.Minute),
+			}, FlowKey{}, FlowStats{})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			_ = len(emitted.IPs)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestClampDNSIPExpiry(t *testing.T) {
+	lastSeen := time.Now()
+
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		minTTL time.Duration
+		maxTTL time.Duration
+		want   time.Duration
+	}{
+		{name: "within bounds", ttl: 5 * time.Minute, minTTL: 30 * time.Second, maxTTL: time.Hour, want: 5 * time.Minute},
+		{name: "below floor", ttl: 0, minTTL: 30 * time.Second, maxTTL: time.Hour, want: 30 * time.Second},
+		{name: "above ceiling", ttl: 24 * time.Hour, minTTL: 30 * time.Second, maxTTL: time.Hour, want: time.Hour},
+		{name: "ceiling still clamped to DefaultMaxAge", ttl: 24 * time.Hour, minTTL: 30 * time.Second, maxTTL: 2 * DefaultMaxAge, want: DefaultMaxAge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := clampDNSIPExpiry(lastSeen, lastSeen.Add(tt.ttl), tt.minTTL, tt.maxTTL).Sub(lastSeen)
+			if got != tt.want {
+				t.Errorf("clampDNSIPExpiry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package externaltrafficholder
+
+import "time"
+
+type Protocol string
+
+const (
+	ProtocolTCP Protocol = "TCP"
+	ProtocolUDP Protocol = "UDP"
+)
+
+// FlowKey is the fine-grained 5-tuple identifying a single flow, used to dedup/accumulate
+// repeated short-lived connections before they collapse into the coarser ExternalTrafficKey
+// (client identity, dest DNS name/IP) that the holder reports on.
+type FlowKey struct {
+	ClientIP   IP
+	ClientPort uint16
+	DestIP     IP
+	DestPort   uint16
+	Proto      Protocol
+}
+
+// FlowStats is per-(client,dest) flow-level telemetry, analogous to Tailscale's flowtrack/netlog:
+// cumulative bytes transferred, the observed time span, protocol, and the set of destination
+// ports seen.
+type FlowStats struct {
+	TxBytes   uint64
+	RxBytes   uint64
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Proto     Protocol
+	DestPorts map[uint16]struct{}
+}
+
+// merge returns f with other folded in, accumulating counters rather than overwriting them. A
+// non-nil f.DestPorts is extended in place, so the result shares that map with f.
+func (f FlowStats) merge(other FlowStats) FlowStats {
+	merged := f
+	merged.TxBytes += other.TxBytes
+	merged.RxBytes += other.RxBytes
+
+	if merged.FirstSeen.IsZero() || (!other.FirstSeen.IsZero() && other.FirstSeen.Before(merged.FirstSeen)) {
+		merged.FirstSeen = other.FirstSeen
+	}
+	if other.LastSeen.After(merged.LastSeen) {
+		merged.LastSeen = other.LastSeen
+	}
+	if merged.Proto == "" {
+		merged.Proto = other.Proto
+	}
+
+	if len(other.DestPorts) > 0 {
+		if merged.DestPorts == nil {
+			merged.DestPorts = make(map[uint16]struct{}, len(other.DestPorts))
+		}
+		for port := range other.DestPorts {
+			merged.DestPorts[port] = struct{}{}
+		}
+	}
+
+	return merged
+}
+
+// cloneForEmit returns a copy of f with its own independent copy of DestPorts, mirroring
+// DNSExternalTrafficIntent.cloneForEmit: a copy handed to a consumer that runs outside h.lock (the
+// Subscribe fan-out, or a snapshot Save) must not share a map with the copy retained in the store,
+// or a later AddIntent merging into that key races with the consumer still reading it.
+func (f FlowStats) cloneForEmit() FlowStats {
+	if len(f.DestPorts) == 0 {
+		return f
+	}
+
+	ports := make(map[uint16]struct{}, len(f.DestPorts))
+	for port := range f.DestPorts {
+		ports[port] = struct{}{}
+	}
+	f.DestPorts = ports
+	return f
+}
+
+// FlowStatsReset returns a copy of f that keeps only Proto and LastSeen: the byte counters,
+// FirstSeen and DestPorts are cleared. The holder uses this for
+// copy-on-emit: callbacks receive the accumulated stats, then the stored copy is reset so the next
+// periodic upload reports a per-interval delta instead of an ever-growing total.
+func (f FlowStats) FlowStatsReset() FlowStats {
+	return FlowStats{
+		Proto:    f.Proto,
+		LastSeen: f.LastSeen,
+	}
+}
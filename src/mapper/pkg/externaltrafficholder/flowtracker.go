@@ -0,0 +1,56 @@
+package externaltrafficholder
+
+import "container/list"
+
+type flowStoreEntry struct {
+	key   FlowKey
+	stats FlowStats
+}
+
+// boundedFlowStore bounds the fine-grained flow-level accumulator the same way boundedIntentStore
+// bounds the coarser external traffic intents: least-recently-seen eviction once at capacity, so a
+// scan producing many distinct short-lived 5-tuples can't grow memory without limit.
+type boundedFlowStore struct {
+	capacity int
+	order    *list.List
+	elements map[FlowKey]*list.Element
+}
+
+func newBoundedFlowStore(capacity int) *boundedFlowStore {
+	return &boundedFlowStore{
+		capacity: capacity,
+		order:    list.New(),
+		elements: make(map[FlowKey]*list.Element),
+	}
+}
+
+// Merge folds stats into the existing entry for key (inserting it if new), moves it to the
+// most-recently-seen position, and returns the resulting cumulative stats for that 5-tuple.
+func (s *boundedFlowStore) Merge(key FlowKey, stats FlowStats) FlowStats {
+	if elem, ok := s.elements[key]; ok {
+		entry := elem.Value.(*flowStoreEntry)
+		entry.stats = entry.stats.merge(stats)
+		s.order.MoveToFront(elem)
+		return entry.stats
+	}
+
+	elem := s.order.PushFront(&flowStoreEntry{key: key, stats: stats})
+	s.elements[key] = elem
+
+	if s.capacity > 0 && s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		evicted := oldest.Value.(*flowStoreEntry)
+		s.order.Remove(oldest)
+		delete(s.elements, evicted.key)
+	}
+
+	return stats
+}
+
+func (s *boundedFlowStore) Get(key FlowKey) (FlowStats, bool) {
+	elem, ok := s.elements[key]
+	if !ok {
+		return FlowStats{}, false
+	}
+	return elem.Value.(*flowStoreEntry).stats, true
+}
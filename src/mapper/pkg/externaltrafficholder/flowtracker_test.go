@@ -0,0 +1,87 @@
+package externaltrafficholder
+
+import "testing"
+
+// TestBoundedFlowStoreMergeAccumulates checks that repeated Merge calls for the same key
+// accumulate stats instead of overwriting them, and that Get returns the same accumulated value.
+func TestBoundedFlowStoreMergeAccumulates(t *testing.T) {
+	s := newBoundedFlowStore(0)
+	key := FlowKey{ClientIP: "1.1.1.1", DestIP: "2.2.2.2", DestPort: 443, Proto: ProtocolTCP}
+
+	s.Merge(key, FlowStats{TxBytes: 10, RxBytes: 20})
+	got := s.Merge(key, FlowStats{TxBytes: 5, RxBytes: 7})
+
+	if got.TxBytes != 15 || got.RxBytes != 27 {
+		t.Fatalf("expected accumulated TxBytes=15 RxBytes=27, got %+v", got)
+	}
+
+	stored, ok := s.Get(key)
+	if !ok {
+		t.Fatal("expected Get to find the merged entry")
+	}
+	if stored.TxBytes != 15 || stored.RxBytes != 27 {
+		t.Fatalf("expected Get to return TxBytes=15 RxBytes=27, got %+v", stored)
+	}
+}
+
+// TestBoundedFlowStoreGetMissingKey checks that Get reports ok=false for a key that was never
+// merged in.
+func TestBoundedFlowStoreGetMissingKey(t *testing.T) {
+	s := newBoundedFlowStore(0)
+
+	if _, ok := s.Get(FlowKey{DestIP: "9.9.9.9"}); ok {
+		t.Fatal("expected Get to report no entry for an unknown key")
+	}
+}
+
+// TestBoundedFlowStoreEvictsAtConfiguredCapacity checks that capacity is honored the same way
+// boundedIntentStore's is, and that the least-recently-touched key is the one evicted.
+func TestBoundedFlowStoreEvictsAtConfiguredCapacity(t *testing.T) {
+	const capacity = 3
+	s := newBoundedFlowStore(capacity)
+
+	var keys []FlowKey
+	for i := 0; i < capacity+2; i++ {
+		key := FlowKey{DestIP: IP(string(rune('a' + i)))}
+		keys = append(keys, key)
+		s.Merge(key, FlowStats{TxBytes: 1})
+	}
+
+	if len(s.elements) != capacity {
+		t.Fatalf("expected store to hold %d entries, got %d", capacity, len(s.elements))
+	}
+	for _, key := range keys[:2] {
+		if _, ok := s.Get(key); ok {
+			t.Fatalf("expected oldest key %v to be evicted", key)
+		}
+	}
+	for _, key := range keys[2:] {
+		if _, ok := s.Get(key); !ok {
+			t.Fatalf("expected recent key %v to survive eviction", key)
+		}
+	}
+}
+
+// TestBoundedFlowStoreMergeRefreshesRecency checks that Merge on an existing key moves it to the
+// most-recently-seen position, so a hot flow isn't evicted ahead of a colder one just because it
+// was inserted first.
+func TestBoundedFlowStoreMergeRefreshesRecency(t *testing.T) {
+	const capacity = 2
+	s := newBoundedFlowStore(capacity)
+
+	first := FlowKey{DestIP: "1.1.1.1"}
+	second := FlowKey{DestIP: "2.2.2.2"}
+	third := FlowKey{DestIP: "3.3.3.3"}
+
+	s.Merge(first, FlowStats{TxBytes: 1})
+	s.Merge(second, FlowStats{TxBytes: 1})
+	s.Merge(first, FlowStats{TxBytes: 1}) // touch first again, so second is now the oldest
+	s.Merge(third, FlowStats{TxBytes: 1}) // pushes the store over capacity
+
+	if _, ok := s.Get(second); ok {
+		t.Fatal("expected second to be evicted as the least-recently-touched entry")
+	}
+	if _, ok := s.Get(first); !ok {
+		t.Fatal("expected first to survive since it was re-touched after second")
+	}
+}
@@ -0,0 +1,97 @@
+package externaltrafficholder
+
+import "container/list"
+
+// intentStoreEntry is the value held by each element of a boundedIntentStore's recency list.
+type intentStoreEntry struct {
+	key    ExternalTrafficKey
+	intent TimestampedExternalTrafficIntent
+}
+
+// boundedIntentStore is a capacity-bounded, least-recently-seen store of external traffic
+// intents, modeled on the known/attempted peer containers in the go-ethereum dashboard: once full,
+// adding a new key evicts the entry that was least recently touched instead of growing forever.
+// A capacity of 0 means unbounded.
+type boundedIntentStore struct {
+	capacity int
+	order    *list.List
+	elements map[ExternalTrafficKey]*list.Element
+}
+
+func newBoundedIntentStore(capacity int) *boundedIntentStore {
+	return &boundedIntentStore{
+		capacity: capacity,
+		order:    list.New(),
+		elements: make(map[ExternalTrafficKey]*list.Element),
+	}
+}
+
+func (s *boundedIntentStore) Len() int {
+	return s.order.Len()
+}
+
+func (s *boundedIntentStore) Get(key ExternalTrafficKey) (TimestampedExternalTrafficIntent, bool) {
+	elem, ok := s.elements[key]
+	if !ok {
+		return TimestampedExternalTrafficIntent{}, false
+	}
+	return elem.Value.(*intentStoreEntry).intent, true
+}
+
+// Set inserts or updates key, moving it to the most-recently-seen position. If inserting a new key
+// pushes the store over capacity, the least-recently-seen entry is evicted and returned.
+func (s *boundedIntentStore) Set(key ExternalTrafficKey, intent TimestampedExternalTrafficIntent) (evicted *intentStoreEntry) {
+	if elem, ok := s.elements[key]; ok {
+		elem.Value.(*intentStoreEntry).intent = intent
+		s.order.MoveToFront(elem)
+		return nil
+	}
+
+	elem := s.order.PushFront(&intentStoreEntry{key: key, intent: intent})
+	s.elements[key] = elem
+
+	if s.capacity > 0 && s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		evicted = oldest.Value.(*intentStoreEntry)
+		s.order.Remove(oldest)
+		delete(s.elements, evicted.key)
+	}
+	return evicted
+}
+
+func (s *boundedIntentStore) Delete(key ExternalTrafficKey) {
+	elem, ok := s.elements[key]
+	if !ok {
+		return
+	}
+	s.order.Remove(elem)
+	delete(s.elements, key)
+}
+
+// Range calls fn for every entry without disturbing its recency order. fn may request deletion of
+// the current entry by returning false. Entries are visited least-recently-seen first, so an fn
+// that Sets the current entry moves each one to the front in turn and leaves their relative order
+// unchanged.
+func (s *boundedIntentStore) Range(fn func(key ExternalTrafficKey, intent TimestampedExternalTrafficIntent) (keep bool)) {
+	for elem := s.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		entry := elem.Value.(*intentStoreEntry)
+		if !fn(entry.key, entry.intent) {
+			s.order.Remove(elem)
+			delete(s.elements, entry.key)
+		}
+		elem = prev
+	}
+}
+
+// Find returns the first entry for which fn returns true, without disturbing recency order or
+// scanning further once found.
+func (s *boundedIntentStore) Find(fn func(key ExternalTrafficKey, intent TimestampedExternalTrafficIntent) bool) (ExternalTrafficKey, TimestampedExternalTrafficIntent, bool) {
+	for elem := s.order.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*intentStoreEntry)
+		if fn(entry.key, entry.intent) {
+			return entry.key, entry.intent, true
+		}
+	}
+	return ExternalTrafficKey{}, TimestampedExternalTrafficIntent{}, false
+}
@@ -0,0 +1,41 @@
+package externaltrafficholder
+
+import "testing"
+
+// TestBoundedIntentStoreEvictsAtConfiguredCapacity checks that capacity is honored whatever value
+// the caller passes in, since NewExternalTrafficIntentsHolder now sources it from config rather
+// than a hardcoded constant.
+func TestBoundedIntentStoreEvictsAtConfiguredCapacity(t *testing.T) {
+	const capacity = 3
+	s := newBoundedIntentStore(capacity)
+
+	var evictedKeys []ExternalTrafficKey
+	for i := 0; i < capacity+2; i++ {
+		key := ExternalTrafficKey{ClientName: "client", DestIP: IP(string(rune('a' + i)))}
+		if evicted := s.Set(key, TimestampedExternalTrafficIntent{}); evicted != nil {
+			evictedKeys = append(evictedKeys, evicted.key)
+		}
+	}
+
+	if s.Len() != capacity {
+		t.Fatalf("expected store to hold %d entries, got %d", capacity, s.Len())
+	}
+	if len(evictedKeys) != 2 {
+		t.Fatalf("expected 2 evictions, got %d", len(evictedKeys))
+	}
+}
+
+func TestBoundedIntentStoreUnboundedAtZeroCapacity(t *testing.T) {
+	s := newBoundedIntentStore(0)
+
+	for i := 0; i < 100; i++ {
+		key := ExternalTrafficKey{ClientName: "client", DestIP: IP(string(rune('a' + i)))}
+		if evicted := s.Set(key, TimestampedExternalTrafficIntent{}); evicted != nil {
+			t.Fatalf("capacity 0 should never evict, got eviction of %v", evicted.key)
+		}
+	}
+
+	if s.Len() != 100 {
+		t.Fatalf("expected 100 entries, got %d", s.Len())
+	}
+}
@@ -0,0 +1,40 @@
+package externaltrafficholder
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus counters for the bounded intent stores, so operators can size the caps instead of
+// guessing from a silently shrinking intent count.
+var (
+	intentsAddedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "network_mapper_external_traffic_intents_added_total",
+		Help: "External traffic intents added as new entries, by store.",
+	}, []string{"store"})
+
+	intentsMergedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "network_mapper_external_traffic_intents_merged_total",
+		Help: "External traffic intents merged into an existing entry, by store.",
+	}, []string{"store"})
+
+	intentsEvictedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "network_mapper_external_traffic_intents_evicted_total",
+		Help: "External traffic intents evicted because their store reached capacity, by store.",
+	}, []string{"store"})
+
+	intentsDroppedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "network_mapper_external_traffic_intents_dropped_total",
+		Help: "External traffic intents dropped before being stored, by reason.",
+	}, []string{"reason"})
+
+	subscriptionPublishDroppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "network_mapper_external_traffic_subscription_publish_dropped_total",
+		Help: "Upsert events dropped because the fan-out hand-off buffer was full.",
+	})
+
+	eventPublishDroppedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "network_mapper_external_traffic_event_publish_dropped_total",
+		Help: "Intent events dropped from RegisterNotifyEvents delivery because the fan-out hand-off buffer was full.",
+	})
+)
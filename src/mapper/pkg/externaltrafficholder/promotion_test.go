@@ -0,0 +1,110 @@
+package externaltrafficholder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DrDroidLab/droid-network-mapper/src/mapper/pkg/graph/model"
+)
+
+// TestIPIntentPromotedAfterThreshold verifies an IP-only intent observed
+// establishedPromotionThreshold times is promoted into storeEstablished even though it never
+// resolved via DNS, and is reported by GetNewIntentsSinceLastGet alongside DNS-resolved intents.
+func TestIPIntentPromotedAfterThreshold(t *testing.T) {
+	h := NewExternalTrafficIntentsHolder(context.Background(), nil)
+	client := model.OtterizeServiceIdentity{Name: "client", Namespace: "default"}
+	key := ExternalTrafficKey{ClientName: client.Name, ClientNamespace: client.Namespace, DestIP: "8.8.8.8"}
+
+	threshold := establishedPromotionThreshold()
+	for i := 0; i < threshold; i++ {
+		h.AddIntent(IPExternalTrafficIntent{
+			Client:   client,
+			LastSeen: time.Now(),
+			IP:       "8.8.8.8",
+		}, FlowKey{}, FlowStats{})
+	}
+
+	if _, ok := h.delayedIPIntents.Get(key); ok {
+		t.Fatal("expected intent to be promoted out of storeAttempted")
+	}
+	stored, ok := h.intentsNoDelay.Get(key)
+	if !ok {
+		t.Fatal("expected intent to be promoted into storeEstablished")
+	}
+	if stored.ObservedCount != threshold {
+		t.Fatalf("expected ObservedCount %d, got %d", threshold, stored.ObservedCount)
+	}
+}
+
+// TestPostPromotionIntentMergesIntoEstablished verifies that once an IP-only intent has been
+// promoted into storeEstablished, further observations accumulate on that entry instead of
+// creating a fresh storeAttempted entry that would reset ObservedCount/Flow.
+func TestPostPromotionIntentMergesIntoEstablished(t *testing.T) {
+	h := NewExternalTrafficIntentsHolder(context.Background(), nil)
+	client := model.OtterizeServiceIdentity{Name: "client", Namespace: "default"}
+	key := ExternalTrafficKey{ClientName: client.Name, ClientNamespace: client.Namespace, DestIP: "8.8.8.8"}
+
+	threshold := establishedPromotionThreshold()
+	for i := 0; i < threshold; i++ {
+		h.AddIntent(IPExternalTrafficIntent{
+			Client:   client,
+			LastSeen: time.Now(),
+			IP:       "8.8.8.8",
+		}, FlowKey{}, FlowStats{})
+	}
+
+	h.AddIntent(IPExternalTrafficIntent{
+		Client:   client,
+		LastSeen: time.Now(),
+		IP:       "8.8.8.8",
+	}, FlowKey{}, FlowStats{})
+
+	if _, ok := h.delayedIPIntents.Get(key); ok {
+		t.Fatal("expected the post-promotion observation to stay out of storeAttempted")
+	}
+	stored, ok := h.intentsNoDelay.Get(key)
+	if !ok {
+		t.Fatal("expected the established entry to still be present")
+	}
+	if stored.ObservedCount != threshold+1 {
+		t.Fatalf("expected ObservedCount %d, got %d", threshold+1, stored.ObservedCount)
+	}
+}
+
+// TestUnconfirmedIPIntentStaysAttemptedAcrossRotation verifies that GetNewIntentsSinceLastGet's
+// rotation reports a storeAttempted entry without promoting it into storeEstablished: only
+// crossing establishedPromotionThreshold via AddIntent should do that, or a scan/amplification
+// burst of single-observation IPs would ride the rotation straight into - and exhaust the capacity
+// of - the established tier.
+func TestUnconfirmedIPIntentStaysAttemptedAcrossRotation(t *testing.T) {
+	h := NewExternalTrafficIntentsHolder(context.Background(), nil)
+	client := model.OtterizeServiceIdentity{Name: "client", Namespace: "default"}
+	key := ExternalTrafficKey{ClientName: client.Name, ClientNamespace: client.Namespace, DestIP: "9.9.9.9"}
+
+	h.AddIntent(IPExternalTrafficIntent{
+		Client:   client,
+		LastSeen: time.Now(),
+		IP:       "9.9.9.9",
+	}, FlowKey{}, FlowStats{})
+
+	for i := 0; i < 3; i++ {
+		reported := h.GetNewIntentsSinceLastGet()
+		found := false
+		for _, intent := range reported {
+			if intent.Intent.GetKey() == key {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("round %d: expected the attempted intent to be reported", i)
+		}
+	}
+
+	if _, ok := h.intentsNoDelay.Get(key); ok {
+		t.Fatal("expected an unconfirmed intent to never be promoted into storeEstablished by rotation alone")
+	}
+	if _, ok := h.delayedIPIntents.Get(key); !ok {
+		t.Fatal("expected the intent to remain in storeAttempted")
+	}
+}
@@ -0,0 +1,182 @@
+package externaltrafficholder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/DrDroidLab/droid-network-mapper/src/mapper/pkg/cloudclient"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotFormatVersion guards the on-disk/ConfigMap document shape. Bump it whenever
+// snapshotDocument or its nested types change incompatibly, and teach Load to cope with older
+// versions (or discard them) so an upgrade can't wedge on a stale snapshot.
+const snapshotFormatVersion = 1
+
+// Snapshotter persists the holder's pending intents across restarts, so a long-tail DNS name that
+// only resolves once an hour doesn't lose its place in line every time the mapper restarts.
+type Snapshotter interface {
+	Save(ctx context.Context, intents []TimestampedExternalTrafficIntent) error
+	Load(ctx context.Context) ([]TimestampedExternalTrafficIntent, error)
+}
+
+type snapshotDocument struct {
+	Version int              `json:"version"`
+	Intents []snapshotIntent `json:"intents"`
+}
+
+// snapshotIntent is the serializable form of TimestampedExternalTrafficIntent: the Intent field's
+// concrete type is flattened out explicitly since ExternalTrafficIntent is an interface and can't
+// round-trip through encoding/json on its own.
+type snapshotIntent struct {
+	Timestamp        time.Time                     `json:"timestamp"`
+	Kind             string                        `json:"kind"` // "dns" or "ip"
+	DNS              *DNSExternalTrafficIntent     `json:"dns,omitempty"`
+	IP               *IPExternalTrafficIntent      `json:"ip,omitempty"`
+	ConnectionsCount *cloudclient.ConnectionsCount `json:"connectionsCount,omitempty"`
+	ObservedCount    int                           `json:"observedCount,omitempty"`
+	Flow             FlowStats                     `json:"flow,omitempty"`
+}
+
+const (
+	snapshotKindDNS = "dns"
+	snapshotKindIP  = "ip"
+)
+
+func toSnapshotIntent(intent TimestampedExternalTrafficIntent) (snapshotIntent, bool) {
+	switch typed := intent.Intent.(type) {
+	case DNSExternalTrafficIntent:
+		return snapshotIntent{
+			Timestamp:        intent.Timestamp,
+			Kind:             snapshotKindDNS,
+			DNS:              &typed,
+			ConnectionsCount: intent.ConnectionsCount,
+			ObservedCount:    intent.ObservedCount,
+			Flow:             intent.Flow,
+		}, true
+	case IPExternalTrafficIntent:
+		return snapshotIntent{
+			Timestamp:        intent.Timestamp,
+			Kind:             snapshotKindIP,
+			IP:               &typed,
+			ConnectionsCount: intent.ConnectionsCount,
+			ObservedCount:    intent.ObservedCount,
+			Flow:             intent.Flow,
+		}, true
+	default:
+		return snapshotIntent{}, false
+	}
+}
+
+func fromSnapshotIntent(s snapshotIntent) (TimestampedExternalTrafficIntent, bool) {
+	switch s.Kind {
+	case snapshotKindDNS:
+		if s.DNS == nil {
+			return TimestampedExternalTrafficIntent{}, false
+		}
+		return TimestampedExternalTrafficIntent{
+			Timestamp:        s.Timestamp,
+			Intent:           *s.DNS,
+			ConnectionsCount: s.ConnectionsCount,
+			ObservedCount:    s.ObservedCount,
+			Flow:             s.Flow,
+		}, true
+	case snapshotKindIP:
+		if s.IP == nil {
+			return TimestampedExternalTrafficIntent{}, false
+		}
+		return TimestampedExternalTrafficIntent{
+			Timestamp:        s.Timestamp,
+			Intent:           *s.IP,
+			ConnectionsCount: s.ConnectionsCount,
+			ObservedCount:    s.ObservedCount,
+			Flow:             s.Flow,
+		}, true
+	default:
+		return TimestampedExternalTrafficIntent{}, false
+	}
+}
+
+func marshalSnapshot(intents []TimestampedExternalTrafficIntent) ([]byte, error) {
+	doc := snapshotDocument{
+		Version: snapshotFormatVersion,
+		Intents: make([]snapshotIntent, 0, len(intents)),
+	}
+	for _, intent := range intents {
+		if s, ok := toSnapshotIntent(intent); ok {
+			doc.Intents = append(doc.Intents, s)
+		}
+	}
+	return json.Marshal(doc)
+}
+
+func unmarshalSnapshot(data []byte) ([]TimestampedExternalTrafficIntent, error) {
+	var doc snapshotDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("unmarshal external traffic intents snapshot: %w", err)
+	}
+
+	if doc.Version != snapshotFormatVersion {
+		return nil, fmt.Errorf("unsupported external traffic intents snapshot version %d, want %d", doc.Version, snapshotFormatVersion)
+	}
+
+	intents := make([]TimestampedExternalTrafficIntent, 0, len(doc.Intents))
+	for _, s := range doc.Intents {
+		if intent, ok := fromSnapshotIntent(s); ok {
+			intents = append(intents, intent)
+		}
+	}
+	return intents, nil
+}
+
+// FileSnapshotter persists intents as a JSON document at Path. It's the simplest Snapshotter and
+// is suited to a mapper running as a single pod with a persistent volume.
+type FileSnapshotter struct {
+	Path string
+}
+
+func NewFileSnapshotter(path string) *FileSnapshotter {
+	return &FileSnapshotter{Path: path}
+}
+
+func (f *FileSnapshotter) Save(_ context.Context, intents []TimestampedExternalTrafficIntent) error {
+	data, err := marshalSnapshot(intents)
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+
+	dir := filepath.Dir(f.Path)
+	tmp, err := os.CreateTemp(dir, ".externaltrafficintents-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create snapshot temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write snapshot temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close snapshot temp file: %w", err)
+	}
+
+	// Rename is atomic on the same filesystem, so readers never see a partially written snapshot.
+	if err := os.Rename(tmp.Name(), f.Path); err != nil {
+		return fmt.Errorf("rename snapshot into place: %w", err)
+	}
+	return nil
+}
+
+func (f *FileSnapshotter) Load(_ context.Context) ([]TimestampedExternalTrafficIntent, error) {
+	data, err := os.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read snapshot file: %w", err)
+	}
+
+	return unmarshalSnapshot(data)
+}
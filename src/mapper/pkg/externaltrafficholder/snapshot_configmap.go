@@ -0,0 +1,78 @@
+package externaltrafficholder
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// snapshotConfigMapDataKey is the key under which the JSON snapshot document is stored in the
+// ConfigMap's Data map.
+const snapshotConfigMapDataKey = "intents.json"
+
+// ConfigMapSnapshotter persists intents in a Kubernetes ConfigMap, for in-cluster deployments where
+// the mapper pod has no persistent volume but does have a ServiceAccount that can read/write a
+// ConfigMap in its own namespace.
+type ConfigMapSnapshotter struct {
+	Client    kubernetes.Interface
+	Namespace string
+	Name      string
+}
+
+func NewConfigMapSnapshotter(client kubernetes.Interface, namespace, name string) *ConfigMapSnapshotter {
+	return &ConfigMapSnapshotter{Client: client, Namespace: namespace, Name: name}
+}
+
+func (c *ConfigMapSnapshotter) Save(ctx context.Context, intents []TimestampedExternalTrafficIntent) error {
+	data, err := marshalSnapshot(intents)
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+
+	configMaps := c.Client.CoreV1().ConfigMaps(c.Namespace)
+
+	existing, err := configMaps.Get(ctx, c.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: c.Name, Namespace: c.Namespace},
+			Data:       map[string]string{snapshotConfigMapDataKey: string(data)},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("create snapshot configmap: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get snapshot configmap: %w", err)
+	}
+
+	if existing.Data == nil {
+		existing.Data = make(map[string]string)
+	}
+	existing.Data[snapshotConfigMapDataKey] = string(data)
+
+	if _, err := configMaps.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update snapshot configmap: %w", err)
+	}
+	return nil
+}
+
+func (c *ConfigMapSnapshotter) Load(ctx context.Context) ([]TimestampedExternalTrafficIntent, error) {
+	configMap, err := c.Client.CoreV1().ConfigMaps(c.Namespace).Get(ctx, c.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot configmap: %w", err)
+	}
+
+	data, ok := configMap.Data[snapshotConfigMapDataKey]
+	if !ok {
+		return nil, nil
+	}
+
+	return unmarshalSnapshot([]byte(data))
+}
@@ -0,0 +1,117 @@
+package externaltrafficholder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DrDroidLab/droid-network-mapper/src/mapper/pkg/graph/model"
+)
+
+// fakeSnapshotter is a Snapshotter that returns a fixed set of intents from Load and discards
+// whatever is passed to Save, so tests can exercise loadSnapshot without touching disk.
+type fakeSnapshotter struct {
+	intents []TimestampedExternalTrafficIntent
+}
+
+func (f *fakeSnapshotter) Save(context.Context, []TimestampedExternalTrafficIntent) error {
+	return nil
+}
+
+func (f *fakeSnapshotter) Load(context.Context) ([]TimestampedExternalTrafficIntent, error) {
+	return f.intents, nil
+}
+
+// TestSnapshotRoundTripPreservesFlowAndObservedCount guards against regressions like the one
+// introduced when FlowStats was added to TimestampedExternalTrafficIntent without teaching
+// toSnapshotIntent/fromSnapshotIntent about it: every field that survives a restart must round-trip
+// through marshalSnapshot/unmarshalSnapshot.
+func TestSnapshotRoundTripPreservesFlowAndObservedCount(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	original := []TimestampedExternalTrafficIntent{
+		{
+			Timestamp: now,
+			Intent: DNSExternalTrafficIntent{
+				Client:   model.OtterizeServiceIdentity{Name: "client", Namespace: "default"},
+				LastSeen: now,
+				DNSName:  "example.com",
+				IPs:      map[IP]dnsIPRecord{"1.1.1.1": {LastSeen: now, ExpiresAt: now.Add(time.Minute)}},
+				TTL:      now.Add(time.Minute),
+			},
+			ObservedCount: 3,
+			Flow: FlowStats{
+				TxBytes:   100,
+				RxBytes:   200,
+				FirstSeen: now,
+				LastSeen:  now,
+				Proto:     ProtocolTCP,
+				DestPorts: map[uint16]struct{}{443: {}},
+			},
+		},
+		{
+			Timestamp: now,
+			Intent: IPExternalTrafficIntent{
+				Client:   model.OtterizeServiceIdentity{Name: "client", Namespace: "default"},
+				LastSeen: now,
+				IP:       "8.8.8.8",
+			},
+			ObservedCount: 7,
+			Flow: FlowStats{
+				TxBytes: 50,
+				Proto:   ProtocolUDP,
+			},
+		},
+	}
+
+	data, err := marshalSnapshot(original)
+	if err != nil {
+		t.Fatalf("marshalSnapshot: %v", err)
+	}
+
+	restored, err := unmarshalSnapshot(data)
+	if err != nil {
+		t.Fatalf("unmarshalSnapshot: %v", err)
+	}
+
+	if len(restored) != len(original) {
+		t.Fatalf("expected %d intents, got %d", len(original), len(restored))
+	}
+
+	for i, want := range original {
+		got := restored[i]
+		if got.ObservedCount != want.ObservedCount {
+			t.Errorf("intent %d: ObservedCount = %d, want %d", i, got.ObservedCount, want.ObservedCount)
+		}
+		if got.Flow.TxBytes != want.Flow.TxBytes || got.Flow.RxBytes != want.Flow.RxBytes || got.Flow.Proto != want.Flow.Proto {
+			t.Errorf("intent %d: Flow = %+v, want %+v", i, got.Flow, want.Flow)
+		}
+	}
+}
+
+// TestLoadSnapshotRoutesByObservedCount checks that an IP-only intent restored from a snapshot
+// lands back in storeEstablished rather than storeAttempted when its persisted ObservedCount had
+// already crossed establishedPromotionThreshold before the restart - a durable ObservedCount (per
+// the AddIntent fix that stops it being reset on every post-promotion observation) is exactly what
+// this routing depends on.
+func TestLoadSnapshotRoutesByObservedCount(t *testing.T) {
+	now := time.Now()
+	client := model.OtterizeServiceIdentity{Name: "client", Namespace: "default"}
+	threshold := establishedPromotionThreshold()
+
+	established := IPExternalTrafficIntent{Client: client, LastSeen: now, IP: "8.8.8.8"}
+	attempted := IPExternalTrafficIntent{Client: client, LastSeen: now, IP: "9.9.9.9"}
+
+	snapshotter := &fakeSnapshotter{intents: []TimestampedExternalTrafficIntent{
+		{Timestamp: now, Intent: established, ObservedCount: threshold},
+		{Timestamp: now, Intent: attempted, ObservedCount: threshold - 1},
+	}}
+
+	h := NewExternalTrafficIntentsHolder(context.Background(), snapshotter)
+
+	if _, ok := h.intentsNoDelay.Get(established.GetKey()); !ok {
+		t.Error("expected the intent at the promotion threshold to restore into storeEstablished")
+	}
+	if _, ok := h.delayedIPIntents.Get(attempted.GetKey()); !ok {
+		t.Error("expected the intent below the promotion threshold to restore into storeAttempted")
+	}
+}
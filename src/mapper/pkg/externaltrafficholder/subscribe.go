@@ -0,0 +1,100 @@
+package externaltrafficholder
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// subscriberBufferSize bounds each subscriber's channel so a slow consumer can't block the
+// fan-out goroutine or grow memory; once full, the oldest buffered update is dropped in favor of
+// the newest one.
+const subscriberBufferSize = 256
+
+// publishBufferSize bounds the hand-off between AddIntent and the fan-out goroutine, so a burst of
+// intents can't make AddIntent block waiting for a slow fan-out.
+const publishBufferSize = 1024
+
+type subscriber struct {
+	ch        chan TimestampedExternalTrafficIntent
+	namespace string // empty means no filter
+	dropped   uint64 // atomic count of updates dropped because ch was full
+}
+
+// runFanOut is the holder's single fan-out goroutine: it owns delivery to every subscriber so that
+// AddIntent only ever has to do a non-blocking send into h.publish.
+func (h *ExternalTrafficIntentsHolder) runFanOut() {
+	for update := range h.publish {
+		h.subsLock.Lock()
+		for _, sub := range h.subscribers {
+			if sub.namespace != "" && sub.namespace != update.Intent.GetClient().Namespace {
+				continue
+			}
+
+			select {
+			case sub.ch <- update:
+				continue
+			default:
+			}
+
+			// Slow consumer: drop the oldest buffered update to make room for the latest state.
+			select {
+			case <-sub.ch:
+				atomic.AddUint64(&sub.dropped, 1)
+			default:
+			}
+			select {
+			case sub.ch <- update:
+			default:
+			}
+		}
+		h.subsLock.Unlock()
+	}
+}
+
+// Subscribe returns a channel of upsert events - the post-merge state of an intent, each time it is
+// added or materially mutated (a new IP added to a DNS intent, a connection count bump) - along
+// with an unsubscribe function. If namespaceFilter is non-empty, only intents whose client
+// namespace matches are delivered. The channel is closed when unsubscribe is called or ctx is done.
+func (h *ExternalTrafficIntentsHolder) Subscribe(ctx context.Context, namespaceFilter string) (<-chan TimestampedExternalTrafficIntent, func()) {
+	h.fanOutOnce.Do(func() { go h.runFanOut() })
+
+	h.subsLock.Lock()
+	id := h.nextSubID
+	h.nextSubID++
+	sub := &subscriber{
+		ch:        make(chan TimestampedExternalTrafficIntent, subscriberBufferSize),
+		namespace: namespaceFilter,
+	}
+	h.subscribers[id] = sub
+	h.subsLock.Unlock()
+
+	var unsubscribeOnce sync.Once
+	unsubscribe := func() {
+		unsubscribeOnce.Do(func() {
+			h.subsLock.Lock()
+			delete(h.subscribers, id)
+			h.subsLock.Unlock()
+			close(sub.ch)
+		})
+	}
+
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+	}()
+
+	return sub.ch, unsubscribe
+}
+
+// publishUpsert hands the post-merge state of an intent off to the fan-out goroutine. The intent is
+// cloned first since the caller's copy still shares its mutable map fields with what's retained in
+// the store, and the fan-out goroutine/subscribers read it outside h.lock. Non-blocking: if the
+// hand-off buffer is full, the update is dropped rather than stalling AddIntent.
+func (h *ExternalTrafficIntentsHolder) publishUpsert(intent TimestampedExternalTrafficIntent) {
+	select {
+	case h.publish <- intent.cloneForEmit():
+	default:
+		subscriptionPublishDroppedTotal.Inc()
+	}
+}
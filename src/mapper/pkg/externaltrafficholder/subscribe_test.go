@@ -0,0 +1,119 @@
+package externaltrafficholder
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DrDroidLab/droid-network-mapper/src/mapper/pkg/graph/model"
+)
+
+// TestSubscribeDeliversUpsertsAndFiltersByNamespace checks the two behaviors Subscribe promises:
+// a subscriber receives the post-merge state of every upsert, and a namespace filter excludes
+// intents from other clients.
+func TestSubscribeDeliversUpsertsAndFiltersByNamespace(t *testing.T) {
+	h := NewExternalTrafficIntentsHolder(context.Background(), nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, unsubscribe := h.Subscribe(ctx, "team-a")
+	defer unsubscribe()
+
+	h.AddIntent(IPExternalTrafficIntent{
+		Client:   model.OtterizeServiceIdentity{Name: "other-client", Namespace: "team-b"},
+		LastSeen: time.Now(),
+		IP:       "1.1.1.1",
+	}, FlowKey{}, FlowStats{})
+
+	h.AddIntent(IPExternalTrafficIntent{
+		Client:   model.OtterizeServiceIdentity{Name: "client", Namespace: "team-a"},
+		LastSeen: time.Now(),
+		IP:       "2.2.2.2",
+	}, FlowKey{}, FlowStats{})
+
+	select {
+	case update := <-ch:
+		if update.Intent.GetClient().Namespace != "team-a" {
+			t.Fatalf("expected an update for team-a, got namespace %q", update.Intent.GetClient().Namespace)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for subscribed update")
+	}
+
+	select {
+	case update, ok := <-ch:
+		if ok {
+			t.Fatalf("expected no further update for team-b intent, got %+v", update)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestSubscribeUnsubscribeClosesChannel ensures calling the unsubscribe func closes the channel,
+// so range-over-channel consumers terminate instead of blocking forever.
+func TestSubscribeUnsubscribeClosesChannel(t *testing.T) {
+	h := NewExternalTrafficIntentsHolder(context.Background(), nil)
+	ch, unsubscribe := h.Subscribe(context.Background(), "")
+
+	unsubscribe()
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("expected channel to be closed after unsubscribe")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for channel to close")
+	}
+}
+
+// TestPublishedUpsertDoesNotShareMapsWithStore reproduces the race a naive publishUpsert would
+// have: a subscriber reading a published DNS intent's IPs map must not share it with the store,
+// or a concurrent AddIntent merging into the same key races with the subscriber. Run with -race.
+func TestPublishedUpsertDoesNotShareMapsWithStore(t *testing.T) {
+	h := NewExternalTrafficIntentsHolder(context.Background(), nil)
+	client := model.OtterizeServiceIdentity{Name: "client", Namespace: "default"}
+	now := time.Now()
+
+	ch, unsubscribe := h.Subscribe(context.Background(), "")
+	defer unsubscribe()
+
+	h.AddIntent(DNSExternalTrafficIntent{
+		Client:   client,
+		LastSeen: now,
+		DNSName:  "example.com",
+		IPs:      map[IP]dnsIPRecord{"1.1.1.1": {}},
+		TTL:      now.Add(time.Minute),
+	}, FlowKey{}, FlowStats{})
+
+	var update TimestampedExternalTrafficIntent
+	select {
+	case update = <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for published upsert")
+	}
+	dnsIntent := update.Intent.(DNSExternalTrafficIntent)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			h.AddIntent(DNSExternalTrafficIntent{
+				Client:   client,
+				LastSeen: time.Now(),
+				DNSName:  "example.com",
+				IPs:      map[IP]dnsIPRecord{"2.2.2.2": {}},
+				TTL:      time.Now().Add(time.Minute),
+			}, FlowKey{}, FlowStats{})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			_ = len(dnsIntent.IPs)
+		}
+	}()
+	wg.Wait()
+}